@@ -0,0 +1,43 @@
+package zookclient
+
+import (
+    "github.com/kikinteractive/curator-go"
+    "github.com/samuel/go-zookeeper/zk"
+)
+
+// Options carries the explicit ACL to apply when a node is created; updating
+// the data of an existing node leaves its ACL unchanged. A nil Options, or one
+// with an empty ACL, falls back to curator.OPEN_ACL_UNSAFE so that callers who
+// do not care about ACLs see no change in behaviour.
+type Options struct {
+    ACL []zk.ACL
+}
+
+func (o *Options) acl() []zk.ACL {
+    if o == nil || len(o.ACL) == 0 {
+        return curator.OPEN_ACL_UNSAFE
+    }
+    return o.ACL
+}
+
+// WorldACL returns a single ACL entry granting perms to the "world:anyone" id.
+func WorldACL(perms int32) zk.ACL {
+    return zk.WorldACL(perms)[0]
+}
+
+// DigestACL returns a single ACL entry granting perms to user, authenticated
+// with the "digest" scheme using pass.
+func DigestACL(user, pass string, perms int32) zk.ACL {
+    return zk.DigestACL(perms, user, pass)[0]
+}
+
+// AddAuth adds scheme:auth credentials to this client's session. It must be
+// called before creating or mutating znodes protected by a digest- or
+// SASL-based ACL that this client should satisfy.
+func (z *ZooKeeperClient) AddAuth(scheme, auth string) error {
+    conn, err := z.curator.ZookeeperClient().Conn()
+    if err != nil {
+        return err
+    }
+    return conn.AddAuth(scheme, []byte(auth))
+}
@@ -0,0 +1,490 @@
+package zookclient
+
+import (
+    "fmt"
+    "log"
+    "sync"
+    "time"
+
+    "github.com/kikinteractive/curator-go"
+    "github.com/samuel/go-zookeeper/zk"
+)
+
+type EventType int8
+
+const (
+    CHILD_ADDED EventType = iota
+    CHILD_UPDATED
+    CHILD_REMOVED
+    INITIALIZED
+)
+
+var eventTypeNames = []string{"CHILD_ADDED", "CHILD_UPDATED", "CHILD_REMOVED", "INITIALIZED"}
+
+func (t EventType) String() string {
+    return eventTypeNames[t]
+}
+
+// Event is dispatched by PathChildrenCache and NodeCache to every listener
+// registered for the path it concerns.
+type Event struct {
+    Type EventType
+    Path string
+    Data []byte
+}
+
+// eventRegistry lets multiple listeners subscribe to the same path without
+// stomping on each other; each subscriber gets its own buffered channel.
+type eventRegistry struct {
+    lock      sync.RWMutex
+    listeners map[string][]chan Event
+}
+
+func newEventRegistry() *eventRegistry {
+    return &eventRegistry{listeners: make(map[string][]chan Event)}
+}
+
+func (r *eventRegistry) subscribe(path string) chan Event {
+    ch := make(chan Event, 16)
+    r.lock.Lock()
+    r.listeners[path] = append(r.listeners[path], ch)
+    r.lock.Unlock()
+    return ch
+}
+
+func (r *eventRegistry) unsubscribe(path string, ch <-chan Event) {
+    r.lock.Lock()
+    defer r.lock.Unlock()
+    subs := r.listeners[path]
+    for i, sub := range subs {
+        if sub == ch {
+            r.listeners[path] = append(subs[:i], subs[i+1:]...)
+            close(sub)
+            return
+        }
+    }
+}
+
+func (r *eventRegistry) publish(path string, ev Event) {
+    r.lock.RLock()
+    defer r.lock.RUnlock()
+    for _, ch := range r.listeners[path] {
+        select {
+        case ch <- ev:
+        default:
+            log.Printf("event listener for %s is not keeping up, dropping %s event", path, ev.Type)
+        }
+    }
+}
+
+// PathChildrenCache maintains an in-memory mirror of basePath's children,
+// an alternative to the one-off watcher that CreatePersistentNode hand-rolls.
+type PathChildrenCache struct {
+    client   *ZooKeeperClient
+    basePath string
+    registry *eventRegistry
+
+    lock     sync.RWMutex
+    data     map[string][]byte
+    watchers map[string]chan struct{}
+
+    state  StateType
+    stopCh chan struct{}
+    doneCh chan struct{}
+}
+
+// WatchChildren creates and starts a PathChildrenCache for basePath.
+func (z *ZooKeeperClient) WatchChildren(basePath string) (*PathChildrenCache, error) {
+    c := &PathChildrenCache{
+        client:   z,
+        basePath: basePath,
+        registry: newEventRegistry(),
+        data:     make(map[string][]byte),
+        watchers: make(map[string]chan struct{}),
+        state:    Latent,
+    }
+    if err := c.start(); err != nil {
+        return nil, err
+    }
+    return c, nil
+}
+
+func (c *PathChildrenCache) start() error {
+    c.lock.Lock()
+    if c.state == Started {
+        c.lock.Unlock()
+        return fmt.Errorf("already started")
+    }
+    c.state = Started
+    c.stopCh = make(chan struct{})
+    c.doneCh = make(chan struct{})
+    c.lock.Unlock()
+
+    children, err := c.client.curator.GetChildren().ForPath(c.basePath)
+    if err != nil {
+        c.lock.Lock()
+        c.state = Latent
+        c.lock.Unlock()
+        return err
+    }
+    c.diff(children)
+    c.registry.publish(c.basePath, Event{Type: INITIALIZED, Path: c.basePath})
+
+    go c.run()
+    return nil
+}
+
+// Close stops the cache and all of its per-child watchers.
+func (c *PathChildrenCache) Close() error {
+    c.lock.Lock()
+    if c.state != Started {
+        c.lock.Unlock()
+        return fmt.Errorf("not started")
+    }
+    c.state = Closed
+    close(c.stopCh)
+    c.lock.Unlock()
+
+    <-c.doneCh
+    return nil
+}
+
+// AddListener subscribes to CHILD_ADDED/CHILD_UPDATED/CHILD_REMOVED events for
+// this cache's path. The INITIALIZED event is published by WatchChildren before
+// it returns, so it is not delivered to listeners added afterwards; CHILD_ADDED
+// events for children present at start may likewise be published before a
+// listener is added.
+func (c *PathChildrenCache) AddListener() <-chan Event {
+    return c.registry.subscribe(c.basePath)
+}
+
+// RemoveListener unsubscribes a channel previously returned by AddListener.
+func (c *PathChildrenCache) RemoveListener(ch <-chan Event) {
+    c.registry.unsubscribe(c.basePath, ch)
+}
+
+// CurrentData returns a snapshot of the cached child data, keyed by child name.
+func (c *PathChildrenCache) CurrentData() map[string][]byte {
+    c.lock.RLock()
+    defer c.lock.RUnlock()
+    snapshot := make(map[string][]byte, len(c.data))
+    for name, data := range c.data {
+        snapshot[name] = data
+    }
+    return snapshot
+}
+
+func (c *PathChildrenCache) run() {
+    defer close(c.doneCh)
+
+    kick := make(chan struct{}, 1)
+    signal := func() {
+        select {
+        case kick <- struct{}{}:
+        default:
+        }
+    }
+
+    reconnectListener := curator.NewConnectionStateListener(
+        func(client curator.CuratorFramework, newState curator.ConnectionState) {
+            if newState == curator.RECONNECTED {
+                signal()
+            }
+        })
+    c.client.curator.ConnectionStateListenable().AddListener(reconnectListener)
+    defer c.client.curator.ConnectionStateListenable().RemoveListener(reconnectListener)
+
+    for {
+        select {
+        case <-c.stopCh:
+            c.stopAllChildWatchers()
+            return
+        default:
+        }
+
+        watcher := curator.NewWatcher(func(ev *zk.Event) { signal() })
+        children, err := c.client.curator.GetChildren().UsingWatcher(watcher).ForPath(c.basePath)
+        if err != nil {
+            log.Printf("path children cache on %s could not list children, error = %v", c.basePath, err)
+        } else {
+            c.diff(children)
+        }
+
+        select {
+        case <-kick:
+        case <-c.stopCh:
+            c.stopAllChildWatchers()
+            return
+        }
+    }
+}
+
+// diff reconciles the currently-known children against the set just read
+// from ZooKeeper, starting a watcher for every new child and stopping the
+// watcher (emitting CHILD_REMOVED) for every child that disappeared.
+func (c *PathChildrenCache) diff(children []string) {
+    current := make(map[string]bool, len(children))
+    for _, name := range children {
+        current[name] = true
+    }
+
+    c.lock.Lock()
+    var added, removed []string
+    for name := range current {
+        if _, ok := c.watchers[name]; !ok {
+            added = append(added, name)
+        }
+    }
+    for name := range c.watchers {
+        if !current[name] {
+            removed = append(removed, name)
+        }
+    }
+    for _, name := range added {
+        stop := make(chan struct{})
+        c.watchers[name] = stop
+        go c.watchChild(name, stop)
+    }
+    for _, name := range removed {
+        stop := c.watchers[name]
+        delete(c.watchers, name)
+        delete(c.data, name)
+        close(stop)
+    }
+    c.lock.Unlock()
+
+    for _, name := range removed {
+        c.registry.publish(c.basePath, Event{Type: CHILD_REMOVED, Path: curator.JoinPath(c.basePath, name)})
+    }
+}
+
+func (c *PathChildrenCache) stopAllChildWatchers() {
+    c.lock.Lock()
+    defer c.lock.Unlock()
+    for name, stop := range c.watchers {
+        close(stop)
+        delete(c.watchers, name)
+        delete(c.data, name)
+    }
+}
+
+// watchChild mirrors a single child's data until it is removed from
+// basePath or stop is closed, publishing CHILD_ADDED on the first read and
+// CHILD_UPDATED on every subsequent change.
+func (c *PathChildrenCache) watchChild(name string, stop chan struct{}) {
+    path := curator.JoinPath(c.basePath, name)
+    first := true
+
+    for {
+        select {
+        case <-stop:
+            return
+        default:
+        }
+
+        changed := make(chan zk.Event, 1)
+        watcher := curator.NewWatcher(func(ev *zk.Event) {
+            select {
+            case changed <- *ev:
+            default:
+            }
+        })
+
+        data, err := c.client.curator.GetData().UsingWatcher(watcher).ForPath(path)
+        if err == zk.ErrNoNode {
+            return
+        }
+        if err != nil {
+            log.Printf("path children cache on %s could not read %s, error = %v", c.basePath, path, err)
+            select {
+            case <-time.After(time.Second):
+            case <-stop:
+                return
+            }
+            continue
+        }
+
+        c.lock.Lock()
+        c.data[name] = data
+        c.lock.Unlock()
+
+        if first {
+            first = false
+            c.registry.publish(c.basePath, Event{Type: CHILD_ADDED, Path: path, Data: data})
+        } else {
+            c.registry.publish(c.basePath, Event{Type: CHILD_UPDATED, Path: path, Data: data})
+        }
+
+        select {
+        case <-changed:
+        case <-stop:
+            return
+        }
+    }
+}
+
+// NodeCache mirrors a single node's data, the companion of PathChildrenCache
+// for watching one znode instead of a subtree.
+type NodeCache struct {
+    client *ZooKeeperClient
+    path   string
+
+    lock     sync.RWMutex
+    data     []byte
+    exists   bool
+    registry *eventRegistry
+
+    state  StateType
+    stopCh chan struct{}
+    doneCh chan struct{}
+}
+
+// NewNodeCache creates a NodeCache for path. Start must be called before it
+// begins mirroring data.
+func NewNodeCache(client *ZooKeeperClient, path string) *NodeCache {
+    return &NodeCache{
+        client:   client,
+        path:     path,
+        registry: newEventRegistry(),
+        state:    Latent,
+    }
+}
+
+// Start begins mirroring the node's data in the background.
+func (n *NodeCache) Start() error {
+    n.lock.Lock()
+    if n.state == Started {
+        n.lock.Unlock()
+        return fmt.Errorf("already started")
+    }
+    n.state = Started
+    n.stopCh = make(chan struct{})
+    n.doneCh = make(chan struct{})
+    n.lock.Unlock()
+
+    go n.run()
+    return nil
+}
+
+// Close stops mirroring the node's data.
+func (n *NodeCache) Close() error {
+    n.lock.Lock()
+    if n.state != Started {
+        n.lock.Unlock()
+        return fmt.Errorf("not started")
+    }
+    n.state = Closed
+    close(n.stopCh)
+    n.lock.Unlock()
+
+    <-n.doneCh
+    return nil
+}
+
+// AddListener subscribes to CHILD_UPDATED/CHILD_REMOVED/INITIALIZED events
+// for this node (CHILD_ADDED is used for the node's initial creation).
+func (n *NodeCache) AddListener() <-chan Event {
+    return n.registry.subscribe(n.path)
+}
+
+// RemoveListener unsubscribes a channel previously returned by AddListener.
+func (n *NodeCache) RemoveListener(ch <-chan Event) {
+    n.registry.unsubscribe(n.path, ch)
+}
+
+// CurrentData returns the last-seen data for the node, or nil if it does not exist.
+func (n *NodeCache) CurrentData() []byte {
+    n.lock.RLock()
+    defer n.lock.RUnlock()
+    return n.data
+}
+
+// Exists reports whether the node existed as of the last observed event.
+func (n *NodeCache) Exists() bool {
+    n.lock.RLock()
+    defer n.lock.RUnlock()
+    return n.exists
+}
+
+func (n *NodeCache) run() {
+    defer close(n.doneCh)
+
+    kick := make(chan struct{}, 1)
+    signal := func() {
+        select {
+        case kick <- struct{}{}:
+        default:
+        }
+    }
+
+    reconnectListener := curator.NewConnectionStateListener(
+        func(client curator.CuratorFramework, newState curator.ConnectionState) {
+            if newState == curator.RECONNECTED {
+                signal()
+            }
+        })
+    n.client.curator.ConnectionStateListenable().AddListener(reconnectListener)
+    defer n.client.curator.ConnectionStateListenable().RemoveListener(reconnectListener)
+
+    initialized := false
+
+    for {
+        select {
+        case <-n.stopCh:
+            return
+        default:
+        }
+
+        changed := make(chan zk.Event, 1)
+        watcher := curator.NewWatcher(func(ev *zk.Event) {
+            select {
+            case changed <- *ev:
+            default:
+            }
+        })
+
+        data, err := n.client.curator.GetData().UsingWatcher(watcher).ForPath(n.path)
+
+        n.lock.Lock()
+        wasExists := n.exists
+        if err == zk.ErrNoNode {
+            n.exists = false
+            n.data = nil
+        } else if err == nil {
+            n.exists = true
+            n.data = data
+        }
+        n.lock.Unlock()
+
+        switch {
+        case err != nil && err != zk.ErrNoNode:
+            log.Printf("node cache on %s could not read node, error = %v", n.path, err)
+        case n.exists && !wasExists:
+            n.registry.publish(n.path, Event{Type: CHILD_ADDED, Path: n.path, Data: data})
+        case n.exists && wasExists:
+            n.registry.publish(n.path, Event{Type: CHILD_UPDATED, Path: n.path, Data: data})
+        case !n.exists && wasExists:
+            n.registry.publish(n.path, Event{Type: CHILD_REMOVED, Path: n.path})
+        }
+
+        if !initialized {
+            initialized = true
+            n.registry.publish(n.path, Event{Type: INITIALIZED, Path: n.path})
+        }
+
+        if err == zk.ErrNoNode {
+            // watch for creation instead of data changes.
+            existsWatcher := curator.NewWatcher(func(ev *zk.Event) { signal() })
+            if _, err := n.client.curator.CheckExists().UsingWatcher(existsWatcher).ForPath(n.path); err != nil {
+                log.Printf("node cache on %s could not watch for creation, error = %v", n.path, err)
+            }
+        }
+
+        select {
+        case <-changed:
+        case <-kick:
+        case <-n.stopCh:
+            return
+        }
+    }
+}
@@ -0,0 +1,101 @@
+package zookclient
+
+import (
+    "encoding/json"
+
+    "github.com/kikinteractive/curator-go"
+    "github.com/samuel/go-zookeeper/zk"
+)
+
+// GetNodeAs unmarshals the node at path into a value of type T, returning its
+// stat alongside so the version can be fed back into SetObjectCAS.
+func GetNodeAs[T any](z *ZooKeeperClient, path string) (T, *zk.Stat, error) {
+    var obj T
+    stat := &zk.Stat{}
+    b, err := z.curator.GetData().StoringStatIn(stat).ForPath(path)
+    if err != nil {
+        return obj, nil, err
+    }
+    if len(b) == 0 {
+        return obj, stat, nil
+    }
+    if err := json.Unmarshal(b, &obj); err != nil {
+        return obj, nil, err
+    }
+    return obj, stat, nil
+}
+
+// GetNodesAs unmarshals every child of path into a value of type T, skipping
+// children with nil or empty data (e.g. bare parent nodes created via
+// CreatingParentsIfNeeded).
+func GetNodesAs[T any](z *ZooKeeperClient, path string) ([]T, error) {
+    children, err := z.GetChildren(path)
+    if err != nil {
+        return nil, err
+    }
+    objs := make([]T, 0, len(children))
+    for _, child := range children {
+        obj, hasData, err := getNodeAsIfPresent[T](z, curator.JoinPath(path, child))
+        if err != nil {
+            return nil, err
+        }
+        if hasData {
+            objs = append(objs, obj)
+        }
+    }
+    return objs, nil
+}
+
+// getNodeAsIfPresent is GetNodeAs plus a hasData flag, so GetNodesAs can skip
+// empty-payload children without a second round trip to read the node again.
+func getNodeAsIfPresent[T any](z *ZooKeeperClient, path string) (obj T, hasData bool, err error) {
+    b, err := z.curator.GetData().ForPath(path)
+    if err != nil {
+        return obj, false, err
+    }
+    if len(b) == 0 {
+        return obj, false, nil
+    }
+    if err := json.Unmarshal(b, &obj); err != nil {
+        return obj, false, err
+    }
+    return obj, true, nil
+}
+
+// SetObjectCAS marshals obj and writes it to path only if the node's current
+// version matches expectedVersion, failing with the underlying zk error
+// (typically zk.ErrBadVersion) on a mismatch.
+func SetObjectCAS[T any](z *ZooKeeperClient, path string, obj T, expectedVersion int32) error {
+    b, err := json.Marshal(obj)
+    if err != nil {
+        return err
+    }
+    _, err = z.curator.SetData().WithVersion(expectedVersion).ForPathWithData(path, b)
+    return err
+}
+
+// GetLiveNode reads a LiveNodeInfo previously registered with RegisterLiveNode.
+func (z *ZooKeeperClient) GetLiveNode(path string) (*LiveNodeInfo, *zk.Stat, error) {
+    info, stat, err := GetNodeAs[LiveNodeInfo](z, path)
+    if err != nil {
+        return nil, nil, err
+    }
+    return &info, stat, nil
+}
+
+// RegisterLiveNode creates an ephemeral node at path carrying info as JSON,
+// using PersistentNode so the node is recreated after every reconnect. The
+// returned PersistentNode must be Close()d to deregister the live node and
+// stop its reconnect watcher; callers that never need to deregister may
+// discard it and let the node live for the life of the ZooKeeperClient.
+func (z *ZooKeeperClient) RegisterLiveNode(path string, info LiveNodeInfo) (*PersistentNode, error) {
+    b, err := json.Marshal(info)
+    if err != nil {
+        return nil, err
+    }
+    node := NewPersistentNode(z.curator, curator.EPHEMERAL, false, path, b)
+    if err := node.Start(); err != nil {
+        return nil, err
+    }
+    return node, nil
+}
@@ -0,0 +1,338 @@
+package zookclient
+
+import (
+    "context"
+    "fmt"
+    "log"
+    "sync"
+    "time"
+
+    "github.com/kikinteractive/curator-go"
+    "github.com/samuel/go-zookeeper/zk"
+)
+
+// LeaderLatch arbitrates leadership of a group of participants using the same
+// ephemeral-sequential + watch-previous recipe as InterProcessMutex; whichever
+// participant owns the lowest-sequenced child of path is the leader.
+type LeaderLatch struct {
+    client *ZooKeeperClient
+    path   string
+    id     string
+
+    lock      sync.Mutex
+    state     StateType
+    isLeader  bool
+    listeners []func(isLeader bool)
+    stopCh    chan struct{}
+    doneCh    chan struct{}
+}
+
+// NewLeaderLatch creates a LeaderLatch that competes for leadership of path;
+// id is stored as the node's data and is purely informational (e.g. for
+// inspecting who currently holds leadership).
+func (z *ZooKeeperClient) NewLeaderLatch(path, id string) *LeaderLatch {
+    return &LeaderLatch{
+        client: z,
+        path:   path,
+        id:     id,
+        state:  Latent,
+    }
+}
+
+// AddListener registers a callback that is invoked with true when this latch
+// gains leadership and with false when it loses it. Listeners must be added
+// before Start.
+func (l *LeaderLatch) AddListener(fn func(isLeader bool)) {
+    l.lock.Lock()
+    defer l.lock.Unlock()
+    l.listeners = append(l.listeners, fn)
+}
+
+// Start begins participating in the election in the background.
+func (l *LeaderLatch) Start() error {
+    l.lock.Lock()
+    if l.state == Started {
+        l.lock.Unlock()
+        return fmt.Errorf("already started")
+    }
+    l.state = Started
+    l.stopCh = make(chan struct{})
+    l.doneCh = make(chan struct{})
+    l.lock.Unlock()
+
+    go l.run()
+    return nil
+}
+
+// Close withdraws from the election, releasing leadership if held, and waits
+// for the background goroutine to exit.
+func (l *LeaderLatch) Close() error {
+    l.lock.Lock()
+    if l.state != Started {
+        l.lock.Unlock()
+        return fmt.Errorf("not started")
+    }
+    l.state = Closed
+    close(l.stopCh)
+    l.lock.Unlock()
+
+    <-l.doneCh
+    return nil
+}
+
+// HasLeadership reports whether this latch currently holds leadership.
+func (l *LeaderLatch) HasLeadership() bool {
+    l.lock.Lock()
+    defer l.lock.Unlock()
+    return l.isLeader
+}
+
+func (l *LeaderLatch) setLeader(isLeader bool) {
+    l.lock.Lock()
+    if l.isLeader == isLeader {
+        l.lock.Unlock()
+        return
+    }
+    l.isLeader = isLeader
+    listeners := make([]func(bool), len(l.listeners))
+    copy(listeners, l.listeners)
+    l.lock.Unlock()
+
+    for _, fn := range listeners {
+        fn(isLeader)
+    }
+}
+
+// run drives the election until Close is called. Disconnects are handled by
+// re-checking our membership whenever the connection state listener wakes the
+// loop; a RECONNECTED after an EXPIRED session recreates our node since the
+// ephemeral node will have disappeared with the old session.
+func (l *LeaderLatch) run() {
+    defer close(l.doneCh)
+
+    kick := make(chan struct{}, 1)
+    signal := func() {
+        select {
+        case kick <- struct{}{}:
+        default:
+        }
+    }
+
+    stateListener := curator.NewConnectionStateListener(
+        func(client curator.CuratorFramework, newState curator.ConnectionState) {
+            switch newState {
+            case curator.RECONNECTED:
+                signal()
+            case curator.LOST, curator.SUSPENDED:
+                l.setLeader(false)
+            }
+        })
+    l.client.curator.ConnectionStateListenable().AddListener(stateListener)
+    defer l.client.curator.ConnectionStateListenable().RemoveListener(stateListener)
+
+    ourPath := ""
+    for {
+        select {
+        case <-l.stopCh:
+            l.withdraw(ourPath)
+            return
+        default:
+        }
+
+        if ourPath == "" || !l.client.Exists(ourPath) {
+            path, err := l.createNode()
+            if err != nil {
+                log.Printf("leader latch on %s could not create node, error = %v", l.path, err)
+                select {
+                case <-time.After(time.Second):
+                case <-l.stopCh:
+                    l.withdraw(ourPath)
+                    return
+                }
+                continue
+            }
+            ourPath = path
+        }
+
+        children, err := l.sortedChildren()
+        if err != nil {
+            log.Printf("leader latch on %s could not list children, error = %v", l.path, err)
+            continue
+        }
+
+        idx := indexOfSequence(children, sequenceOf(curator.GetNodeFromPath(ourPath)))
+        if idx < 0 {
+            // our node is gone, most likely due to an expired session - recreate it.
+            l.setLeader(false)
+            ourPath = ""
+            continue
+        }
+
+        if idx == 0 {
+            l.setLeader(true)
+            select {
+            case <-l.stopCh:
+                l.withdraw(ourPath)
+                return
+            case <-kick:
+                l.setLeader(false)
+            }
+            continue
+        }
+
+        l.setLeader(false)
+        predecessor := curator.JoinPath(l.path, children[idx-1])
+        watcher := curator.NewWatcher(func(ev *zk.Event) { signal() })
+        if _, err := l.client.curator.CheckExists().UsingWatcher(watcher).ForPath(predecessor); err != nil && err != zk.ErrNoNode {
+            log.Printf("leader latch on %s could not watch %s, error = %v", l.path, predecessor, err)
+        }
+
+        select {
+        case <-kick:
+        case <-l.stopCh:
+            l.withdraw(ourPath)
+            return
+        }
+    }
+}
+
+func (l *LeaderLatch) withdraw(ourPath string) {
+    if len(ourPath) > 0 {
+        if err := l.client.curator.Delete().ForPath(ourPath); err != nil && err != zk.ErrNoNode {
+            log.Printf("leader latch on %s could not delete %s, error = %v", l.path, ourPath, err)
+        }
+    }
+    l.setLeader(false)
+}
+
+func (l *LeaderLatch) createNode() (string, error) {
+    prefixPath := curator.JoinPath(l.path, lockPrefix+l.id+lockName)
+    return l.client.curator.Create().WithMode(curator.EPHEMERAL_SEQUENTIAL).CreatingParentsIfNeeded().ForPathWithData(prefixPath, []byte(l.id))
+}
+
+func (l *LeaderLatch) sortedChildren() ([]string, error) {
+    children, err := l.client.curator.GetChildren().ForPath(l.path)
+    if err != nil {
+        return nil, err
+    }
+    return sortSequential(children), nil
+}
+
+// LeaderSelector repeatedly competes for leadership of path; each time it
+// wins, callback is invoked in its own goroutine with a context that is
+// canceled as soon as leadership is lost, and once callback returns the
+// selector re-queues itself for another round of the election.
+type LeaderSelector struct {
+    client   *ZooKeeperClient
+    path     string
+    id       string
+    callback func(ctx context.Context)
+
+    lock    sync.Mutex
+    latch   *LeaderLatch
+    cancel  context.CancelFunc
+    running bool
+    closed  bool
+}
+
+// NewLeaderSelector creates a LeaderSelector that runs callback every time it
+// acquires leadership of path.
+func (z *ZooKeeperClient) NewLeaderSelector(path, id string, callback func(ctx context.Context)) *LeaderSelector {
+    return &LeaderSelector{
+        client:   z,
+        path:     path,
+        id:       id,
+        callback: callback,
+    }
+}
+
+// Start begins participating in the election in the background.
+func (s *LeaderSelector) Start() error {
+    s.lock.Lock()
+    latch := s.client.NewLeaderLatch(s.path, s.id)
+    latch.AddListener(s.onLeadership)
+    s.latch = latch
+    s.lock.Unlock()
+    return latch.Start()
+}
+
+// Close withdraws from the election, canceling an in-progress callback if one
+// is running.
+func (s *LeaderSelector) Close() error {
+    s.lock.Lock()
+    s.closed = true
+    latch := s.latch
+    cancel := s.cancel
+    s.lock.Unlock()
+    if cancel != nil {
+        cancel()
+    }
+    if latch == nil {
+        return fmt.Errorf("not started")
+    }
+    return latch.Close()
+}
+
+// HasLeadership reports whether this selector currently holds leadership.
+func (s *LeaderSelector) HasLeadership() bool {
+    s.lock.Lock()
+    latch := s.latch
+    s.lock.Unlock()
+    return latch != nil && latch.HasLeadership()
+}
+
+func (s *LeaderSelector) onLeadership(isLeader bool) {
+    s.lock.Lock()
+    defer s.lock.Unlock()
+
+    if isLeader {
+        if s.running {
+            return
+        }
+        s.running = true
+        ctx, cancel := context.WithCancel(context.Background())
+        s.cancel = cancel
+        go s.runCallback(ctx)
+    } else if s.cancel != nil {
+        s.cancel()
+    }
+}
+
+func (s *LeaderSelector) runCallback(ctx context.Context) {
+    s.callback(ctx)
+
+    s.lock.Lock()
+    s.running = false
+    s.cancel = nil
+    latch := s.latch
+    closed := s.closed
+    s.lock.Unlock()
+
+    if latch == nil {
+        return
+    }
+    if err := latch.Close(); err != nil {
+        log.Printf("leader selector on %s could not withdraw, error = %v", s.path, err)
+        return
+    }
+    if closed {
+        // Close() raced with us and is the one responsible for the latch
+        // above; do not re-enter the election on its behalf.
+        return
+    }
+
+    newLatch := s.client.NewLeaderLatch(s.path, s.id)
+    newLatch.AddListener(s.onLeadership)
+
+    s.lock.Lock()
+    if s.closed {
+        s.lock.Unlock()
+        return
+    }
+    s.latch = newLatch
+    s.lock.Unlock()
+
+    if err := newLatch.Start(); err != nil {
+        log.Printf("leader selector on %s could not re-queue, error = %v", s.path, err)
+    }
+}
@@ -0,0 +1,105 @@
+package zookclient
+
+import (
+    "context"
+    "sync"
+    "testing"
+    "time"
+)
+
+func TestLeaderLatch_Handoff(t *testing.T) {
+    connStr := embeddedZK(t)
+    client, err := NewZooKeeperClient(connStr)
+    if err != nil {
+        t.Fatalf("NewZooKeeperClient: %v", err)
+    }
+    path := "/test/election/latch"
+
+    first := client.NewLeaderLatch(path, "first")
+    if err := first.Start(); err != nil {
+        t.Fatalf("first.Start: %v", err)
+    }
+    waitForLeadership(t, first, true)
+
+    second := client.NewLeaderLatch(path, "second")
+    if err := second.Start(); err != nil {
+        t.Fatalf("second.Start: %v", err)
+    }
+    if second.HasLeadership() {
+        t.Fatal("second latch reports leadership while first still holds it")
+    }
+
+    if err := first.Close(); err != nil {
+        t.Fatalf("first.Close: %v", err)
+    }
+    waitForLeadership(t, second, true)
+
+    if err := second.Close(); err != nil {
+        t.Fatalf("second.Close: %v", err)
+    }
+}
+
+func waitForLeadership(t *testing.T, latch *LeaderLatch, want bool) {
+    t.Helper()
+    deadline := time.Now().Add(10 * time.Second)
+    for time.Now().Before(deadline) {
+        if latch.HasLeadership() == want {
+            return
+        }
+        time.Sleep(50 * time.Millisecond)
+    }
+    t.Fatalf("latch did not reach HasLeadership()=%v in time", want)
+}
+
+// TestLeaderSelector_CloseDuringCallback exercises the race where Close is
+// called just as a leadership callback returns: the selector must not
+// re-queue itself for another round of the election after Close.
+func TestLeaderSelector_CloseDuringCallback(t *testing.T) {
+    connStr := embeddedZK(t)
+    client, err := NewZooKeeperClient(connStr)
+    if err != nil {
+        t.Fatalf("NewZooKeeperClient: %v", err)
+    }
+    path := "/test/election/selector"
+
+    entered := make(chan struct{}, 1)
+    var runs int
+    var mu sync.Mutex
+
+    selector := client.NewLeaderSelector(path, "only", func(ctx context.Context) {
+        mu.Lock()
+        runs++
+        mu.Unlock()
+        select {
+        case entered <- struct{}{}:
+        default:
+        }
+    })
+
+    if err := selector.Start(); err != nil {
+        t.Fatalf("Start: %v", err)
+    }
+
+    select {
+    case <-entered:
+    case <-time.After(10 * time.Second):
+        t.Fatal("callback never ran")
+    }
+
+    if err := selector.Close(); err != nil {
+        t.Fatalf("Close: %v", err)
+    }
+
+    // Give a buggy re-queue a chance to happen and run the callback again.
+    time.Sleep(500 * time.Millisecond)
+
+    mu.Lock()
+    got := runs
+    mu.Unlock()
+    if got != 1 {
+        t.Fatalf("callback ran %d times after Close, want 1 (selector re-entered the election)", got)
+    }
+    if selector.HasLeadership() {
+        t.Fatal("selector reports leadership after Close")
+    }
+}
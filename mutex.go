@@ -0,0 +1,217 @@
+package zookclient
+
+import (
+    "context"
+    "crypto/rand"
+    "fmt"
+    "sort"
+    "strings"
+    "sync"
+
+    "github.com/kikinteractive/curator-go"
+    "github.com/pkg/errors"
+    "github.com/samuel/go-zookeeper/zk"
+)
+
+const lockPrefix = "_c_"
+const lockName = "-lock-"
+
+// InterProcessMutex implements the standard ZooKeeper lock recipe on top of the
+// curator.CuratorFramework held by ZooKeeperClient; an EPHEMERAL_SEQUENTIAL child
+// of basePath is created, and the lock is held by whichever participant owns the
+// child with the lowest sequence number.
+type InterProcessMutex struct {
+    client   *ZooKeeperClient
+    basePath string
+    lockID   string
+
+    lock     sync.Mutex
+    ourPath  string
+    acquired bool
+}
+
+// NewMutex creates an InterProcessMutex that will arbitrate access to path.
+func (z *ZooKeeperClient) NewMutex(path string) *InterProcessMutex {
+    return &InterProcessMutex{
+        client:   z,
+        basePath: path,
+        lockID:   newLockID(),
+    }
+}
+
+// Acquire blocks until the lock is held or ctx is done. It is safe to call
+// Acquire again after Release, but it is not re-entrant within a single hold.
+func (m *InterProcessMutex) Acquire(ctx context.Context) error {
+    m.lock.Lock()
+    defer m.lock.Unlock()
+    if m.acquired {
+        return errors.New("lock is already held")
+    }
+
+    ourPath, err := m.createLockNode()
+    if err != nil {
+        return errors.Wrap(err, "could not create lock node")
+    }
+    m.ourPath = ourPath
+
+    // ourPath is an EPHEMERAL_SEQUENTIAL node; unless we end up holding the
+    // lock, every return path below must release it so a transient failure
+    // does not leave a permanent placeholder ahead of later acquirers.
+    acquired := false
+    defer func() {
+        if !acquired {
+            m.deleteNode(ourPath)
+            m.ourPath = ""
+        }
+    }()
+
+    lost := make(chan error, 1)
+    listener := curator.NewConnectionStateListener(
+        func(client curator.CuratorFramework, newState curator.ConnectionState) {
+            if newState == curator.LOST || newState == curator.SUSPENDED {
+                select {
+                case lost <- fmt.Errorf("connection %s while acquiring lock %s", newState, m.basePath):
+                default:
+                }
+            }
+        })
+    m.client.curator.ConnectionStateListenable().AddListener(listener)
+    defer m.client.curator.ConnectionStateListenable().RemoveListener(listener)
+
+    for {
+        children, err := m.sortedChildren()
+        if err != nil {
+            return errors.Wrap(err, "could not list lock children")
+        }
+
+        ourSequence := sequenceOf(curator.GetNodeFromPath(ourPath))
+        idx := indexOfSequence(children, ourSequence)
+        if idx < 0 {
+            return fmt.Errorf("our lock node %s disappeared from %s", ourPath, m.basePath)
+        }
+        if idx == 0 {
+            m.acquired = true
+            acquired = true
+            return nil
+        }
+
+        predecessor := curator.JoinPath(m.basePath, children[idx-1])
+        watched := make(chan struct{}, 1)
+        watcher := curator.NewWatcher(func(ev *zk.Event) {
+            select {
+            case watched <- struct{}{}:
+            default:
+            }
+        })
+
+        stat, err := m.client.curator.CheckExists().UsingWatcher(watcher).ForPath(predecessor)
+        if err != nil && err != zk.ErrNoNode {
+            return errors.Wrap(err, "could not watch predecessor lock node")
+        }
+        if stat == nil {
+            // predecessor was already gone by the time we checked - loop and re-evaluate.
+            continue
+        }
+
+        select {
+        case <-watched:
+        case err := <-lost:
+            return err
+        case <-ctx.Done():
+            return ctx.Err()
+        }
+    }
+}
+
+// Release gives up the lock by deleting our sequential node.
+func (m *InterProcessMutex) Release() error {
+    m.lock.Lock()
+    defer m.lock.Unlock()
+    if !m.acquired {
+        return errors.New("lock is not held")
+    }
+    if err := m.deleteNode(m.ourPath); err != nil {
+        return err
+    }
+    m.acquired = false
+    m.ourPath = ""
+    return nil
+}
+
+// IsAcquired reports whether this mutex currently holds the lock.
+func (m *InterProcessMutex) IsAcquired() bool {
+    m.lock.Lock()
+    defer m.lock.Unlock()
+    return m.acquired
+}
+
+func (m *InterProcessMutex) deleteNode(path string) error {
+    if err := m.client.curator.Delete().ForPath(path); err != nil && err != zk.ErrNoNode {
+        return err
+    }
+    return nil
+}
+
+// createLockNode creates our EPHEMERAL_SEQUENTIAL node. If the create response
+// was lost to a network error, it scans the children for one that already
+// contains our lockID rather than creating a duplicate.
+func (m *InterProcessMutex) createLockNode() (string, error) {
+    prefixPath := curator.JoinPath(m.basePath, lockPrefix+m.lockID+lockName)
+    path, err := m.client.curator.Create().WithMode(curator.EPHEMERAL_SEQUENTIAL).CreatingParentsIfNeeded().ForPath(prefixPath)
+    if err == nil {
+        return path, nil
+    }
+
+    children, listErr := m.client.curator.GetChildren().ForPath(m.basePath)
+    if listErr != nil {
+        return "", err
+    }
+    for _, child := range children {
+        if strings.Contains(child, m.lockID) {
+            return curator.JoinPath(m.basePath, child), nil
+        }
+    }
+    return "", err
+}
+
+func (m *InterProcessMutex) sortedChildren() ([]string, error) {
+    children, err := m.client.curator.GetChildren().ForPath(m.basePath)
+    if err != nil {
+        return nil, err
+    }
+    return sortSequential(children), nil
+}
+
+// sortSequential orders sequential child node names by their sequence suffix.
+func sortSequential(children []string) []string {
+    sort.Slice(children, func(i, j int) bool {
+        return sequenceOf(children[i]) < sequenceOf(children[j])
+    })
+    return children
+}
+
+// sequenceOf extracts the sequence suffix that ZooKeeper appends to an
+// EPHEMERAL_SEQUENTIAL node name, e.g. "_c_<uuid>-lock-0000000001" -> "0000000001".
+func sequenceOf(nodeName string) string {
+    if idx := strings.LastIndex(nodeName, lockName); idx >= 0 {
+        return nodeName[idx+len(lockName):]
+    }
+    return nodeName
+}
+
+func indexOfSequence(children []string, sequence string) int {
+    for i, child := range children {
+        if sequenceOf(child) == sequence {
+            return i
+        }
+    }
+    return -1
+}
+
+func newLockID() string {
+    b := make([]byte, 16)
+    if _, err := rand.Read(b); err != nil {
+        return fmt.Sprintf("%p", b)
+    }
+    return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
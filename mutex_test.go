@@ -0,0 +1,149 @@
+package zookclient
+
+import (
+    "context"
+    "sync"
+    "testing"
+    "time"
+)
+
+func TestInterProcessMutex_SingleAcquirer(t *testing.T) {
+    connStr := embeddedZK(t)
+    client, err := NewZooKeeperClient(connStr)
+    if err != nil {
+        t.Fatalf("NewZooKeeperClient: %v", err)
+    }
+
+    m := client.NewMutex("/test/locks/single")
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    if err := m.Acquire(ctx); err != nil {
+        t.Fatalf("Acquire: %v", err)
+    }
+    if !m.IsAcquired() {
+        t.Fatal("IsAcquired returned false after Acquire succeeded")
+    }
+    if err := m.Release(); err != nil {
+        t.Fatalf("Release: %v", err)
+    }
+    if m.IsAcquired() {
+        t.Fatal("IsAcquired returned true after Release")
+    }
+}
+
+// TestInterProcessMutex_ContestedAcquireReleaseOrdering verifies that when
+// several participants race for the same lock, each one acquires it in turn
+// and the critical section is never entered concurrently by two holders.
+func TestInterProcessMutex_ContestedAcquireReleaseOrdering(t *testing.T) {
+    connStr := embeddedZK(t)
+    client, err := NewZooKeeperClient(connStr)
+    if err != nil {
+        t.Fatalf("NewZooKeeperClient: %v", err)
+    }
+
+    const participants = 5
+    path := "/test/locks/contested"
+
+    var (
+        mu         sync.Mutex
+        holders    int
+        maxHolders int
+        completed  int
+    )
+
+    var wg sync.WaitGroup
+    wg.Add(participants)
+    for i := 0; i < participants; i++ {
+        go func() {
+            defer wg.Done()
+            m := client.NewMutex(path)
+            ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+            defer cancel()
+
+            if err := m.Acquire(ctx); err != nil {
+                t.Errorf("Acquire: %v", err)
+                return
+            }
+
+            mu.Lock()
+            holders++
+            if holders > maxHolders {
+                maxHolders = holders
+            }
+            mu.Unlock()
+
+            time.Sleep(50 * time.Millisecond)
+
+            mu.Lock()
+            holders--
+            completed++
+            mu.Unlock()
+
+            if err := m.Release(); err != nil {
+                t.Errorf("Release: %v", err)
+            }
+        }()
+    }
+    wg.Wait()
+
+    if maxHolders != 1 {
+        t.Fatalf("expected at most one concurrent holder, saw %d", maxHolders)
+    }
+    if completed != participants {
+        t.Fatalf("expected %d participants to complete, got %d", participants, completed)
+    }
+}
+
+// TestInterProcessMutex_PredecessorWatchRelist verifies that a blocked
+// acquirer re-lists the lock children and proceeds once its predecessor node
+// is deleted, i.e. the watch set in Acquire actually fires.
+func TestInterProcessMutex_PredecessorWatchRelist(t *testing.T) {
+    connStr := embeddedZK(t)
+    client, err := NewZooKeeperClient(connStr)
+    if err != nil {
+        t.Fatalf("NewZooKeeperClient: %v", err)
+    }
+
+    path := "/test/locks/relist"
+    first := client.NewMutex(path)
+    second := client.NewMutex(path)
+
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+    if err := first.Acquire(ctx); err != nil {
+        t.Fatalf("first.Acquire: %v", err)
+    }
+
+    acquired := make(chan error, 1)
+    go func() {
+        ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+        defer cancel()
+        acquired <- second.Acquire(ctx)
+    }()
+
+    select {
+    case err := <-acquired:
+        t.Fatalf("second.Acquire returned before the lock was released: %v", err)
+    case <-time.After(200 * time.Millisecond):
+    }
+
+    if err := first.Release(); err != nil {
+        t.Fatalf("first.Release: %v", err)
+    }
+
+    select {
+    case err := <-acquired:
+        if err != nil {
+            t.Fatalf("second.Acquire: %v", err)
+        }
+    case <-time.After(10 * time.Second):
+        t.Fatal("second.Acquire did not unblock after first.Release")
+    }
+    if !second.IsAcquired() {
+        t.Fatal("second mutex reports not acquired after Acquire succeeded")
+    }
+    if err := second.Release(); err != nil {
+        t.Fatalf("second.Release: %v", err)
+    }
+}
@@ -22,6 +22,7 @@ type PersistentNode struct {
     useProtection           bool
     basePath                string
     data                    []byte
+    acl                     []zk.ACL
     state                   StateType
     backgroundCallback      curator.BackgroundCallback
     authFailure             bool
@@ -35,6 +36,12 @@ type PersistentNode struct {
 }
 
 func NewPersistentNode(client curator.CuratorFramework, mode curator.CreateMode, protection bool, basePath string, initData []byte) *PersistentNode {
+    return NewPersistentNodeWithOptions(client, mode, protection, basePath, initData, nil)
+}
+
+// NewPersistentNodeWithOptions is NewPersistentNode with an explicit ACL for
+// the node it creates; a nil opts falls back to curator.OPEN_ACL_UNSAFE.
+func NewPersistentNodeWithOptions(client curator.CuratorFramework, mode curator.CreateMode, protection bool, basePath string, initData []byte, opts *Options) *PersistentNode {
     data := make([]byte, len(initData))
     copy(data, initData)
     pNode := &PersistentNode{
@@ -43,6 +50,7 @@ func NewPersistentNode(client curator.CuratorFramework, mode curator.CreateMode,
         useProtection: protection,
         basePath:      basePath,
         data:          data,
+        acl:           opts.acl(),
         lock:          sync.Mutex{},
     }
     pNode.state = Latent
@@ -80,6 +88,9 @@ func NewPersistentNode(client curator.CuratorFramework, mode curator.CreateMode,
         func(client curator.CuratorFramework, ev curator.CuratorEvent) error {
             if ev.Err() == curator.ErrNoNode {
                 pNode.createNode()
+            } else if ev.Err() == curator.ErrNoAuth {
+                log.Printf("client does not have authorisation to read node at path %s", ev.Path())
+                pNode.authFailure = true
             } else {
                 if ev.Stat().EphemeralOwner != curator.EPHEMERAL {
                     log.Printf("Existing node ephemeral state doesn't match requested state. Maybe the node was created outside of PersistentNode? %s", pNode.basePath)
@@ -93,6 +104,9 @@ func NewPersistentNode(client curator.CuratorFramework, mode curator.CreateMode,
             if ev.Err() == curator.ErrNothing {
                 // Initialisation complete
                 pNode.wg.Done()
+            } else if ev.Err() == curator.ErrNoAuth {
+                log.Printf("client does not have authorisation to write node at path %s", ev.Path())
+                pNode.authFailure = true
             }
             return nil
         })
@@ -220,7 +234,7 @@ func (p *PersistentNode) createNode() {
     if len(existingPath) > 0 && !p.useProtection {
         createPath = existingPath
     }
-    createBuilder := p.client.Create().CreatingParentsIfNeeded()
+    createBuilder := p.client.Create().CreatingParentsIfNeeded().WithACL(p.acl...)
     str, err := createBuilder.WithMode(p.getCreateMode(len(existingPath) > 0)).InBackgroundWithCallback(p.backgroundCallback).ForPathWithData(createPath, p.data)
     if err != nil {
         log.Printf("create %s in background failed, error = %v", str, err)
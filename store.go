@@ -0,0 +1,269 @@
+package zookclient
+
+import (
+    "github.com/kikinteractive/curator-go"
+    "github.com/pkg/errors"
+    "github.com/samuel/go-zookeeper/zk"
+)
+
+var (
+    // ErrKeyNotFound is returned by Get when the requested key does not
+    // exist.
+    ErrKeyNotFound = errors.New("key not found in store")
+
+    // ErrKeyExists is returned by AtomicPut when previous is nil but the key
+    // already exists.
+    ErrKeyExists = errors.New("key already exists")
+
+    // ErrKeyModified is returned by AtomicPut and AtomicDelete when previous
+    // does not match the node's current version, or when the node no longer
+    // exists.
+    ErrKeyModified = errors.New("key modified since last read")
+
+    // ErrPreviousNotSpecified is returned by AtomicDelete when previous is nil.
+    ErrPreviousNotSpecified = errors.New("previous K/V pair is required for atomic delete")
+)
+
+// encodedValuePrefix marks a stored value as having been prefixed on write
+// because its first byte would otherwise collide with a ZooKeeper directory
+// marker (a bare NUL/SOH byte).
+const encodedValuePrefix = 0x01
+
+// KVPair is a single key/value entry read from a Store, along with the
+// ZooKeeper node version it was read at.
+type KVPair struct {
+    Key       string
+    Value     []byte
+    LastIndex int64
+}
+
+// WriteOptions controls how a Put is performed.
+type WriteOptions struct {
+    // TTL is accepted for interface compatibility with Store backends that
+    // support real key expiry; ZooKeeper has no such primitive, so it is
+    // currently ignored.
+    TTL int64
+}
+
+// Store is a generic key/value facade over ZooKeeperClient, modelled on
+// libkv/valkeyrie's Store interface, with optimistic-concurrency semantics
+// backed by the underlying znode version.
+type Store interface {
+    Get(key string) (*KVPair, error)
+    Put(key string, value []byte, opts *WriteOptions) error
+    Delete(key string) error
+    Exists(key string) (bool, error)
+    List(prefix string) ([]*KVPair, error)
+    AtomicPut(key string, value []byte, previous *KVPair, opts *WriteOptions) (bool, *KVPair, error)
+    AtomicDelete(key string, previous *KVPair) (bool, error)
+    Watch(key string, stopCh <-chan struct{}) (<-chan *KVPair, error)
+    WatchTree(prefix string, stopCh <-chan struct{}) (<-chan []*KVPair, error)
+}
+
+type zkStore struct {
+    client *ZooKeeperClient
+}
+
+// NewStore returns a Store backed by this client's curator.CuratorFramework.
+func (z *ZooKeeperClient) NewStore() Store {
+    return &zkStore{client: z}
+}
+
+func (s *zkStore) Get(key string) (*KVPair, error) {
+    stat := &zk.Stat{}
+    raw, err := s.client.curator.GetData().StoringStatIn(stat).ForPath(key)
+    if err != nil {
+        if err == zk.ErrNoNode {
+            return nil, ErrKeyNotFound
+        }
+        return nil, err
+    }
+    return &KVPair{Key: key, Value: decodeValue(raw), LastIndex: int64(stat.Version)}, nil
+}
+
+func (s *zkStore) Put(key string, value []byte, opts *WriteOptions) error {
+    return s.client.SetData(key, encodeValue(value), curator.PERSISTENT)
+}
+
+func (s *zkStore) Delete(key string) error {
+    return s.client.Delete(key, false)
+}
+
+func (s *zkStore) Exists(key string) (bool, error) {
+    return exists(s.client.curator, key)
+}
+
+func (s *zkStore) List(prefix string) ([]*KVPair, error) {
+    children, err := s.client.GetChildren(prefix)
+    if err != nil {
+        return nil, err
+    }
+    pairs := make([]*KVPair, 0, len(children))
+    for _, child := range children {
+        kv, err := s.Get(curator.JoinPath(prefix, child))
+        if err != nil {
+            if err == ErrKeyNotFound {
+                continue
+            }
+            return nil, err
+        }
+        pairs = append(pairs, kv)
+    }
+    return pairs, nil
+}
+
+func (s *zkStore) AtomicPut(key string, value []byte, previous *KVPair, opts *WriteOptions) (bool, *KVPair, error) {
+    if previous == nil {
+        if _, err := s.client.curator.Create().CreatingParentsIfNeeded().ForPathWithData(key, encodeValue(value)); err != nil {
+            if err == zk.ErrNodeExists {
+                return false, nil, ErrKeyExists
+            }
+            return false, nil, err
+        }
+        kv, err := s.Get(key)
+        if err != nil {
+            return false, nil, err
+        }
+        return true, kv, nil
+    }
+
+    stat, err := s.client.curator.SetData().WithVersion(int32(previous.LastIndex)).ForPathWithData(key, encodeValue(value))
+    if err != nil {
+        if err == zk.ErrBadVersion || err == zk.ErrNoNode {
+            return false, nil, ErrKeyModified
+        }
+        return false, nil, err
+    }
+    return true, &KVPair{Key: key, Value: value, LastIndex: int64(stat.Version)}, nil
+}
+
+func (s *zkStore) AtomicDelete(key string, previous *KVPair) (bool, error) {
+    if previous == nil {
+        return false, ErrPreviousNotSpecified
+    }
+    if err := s.client.curator.Delete().WithVersion(int32(previous.LastIndex)).ForPath(key); err != nil {
+        if err == zk.ErrBadVersion || err == zk.ErrNoNode {
+            return false, ErrKeyModified
+        }
+        return false, err
+    }
+    return true, nil
+}
+
+func (s *zkStore) Watch(key string, stopCh <-chan struct{}) (<-chan *KVPair, error) {
+    out := make(chan *KVPair, 1)
+    go func() {
+        defer close(out)
+        for {
+            stat := &zk.Stat{}
+            changed := make(chan zk.Event, 1)
+            watcher := curator.NewWatcher(func(ev *zk.Event) {
+                select {
+                case changed <- *ev:
+                default:
+                }
+            })
+
+            raw, err := s.client.curator.GetData().UsingWatcher(watcher).StoringStatIn(stat).ForPath(key)
+            if err != nil && err != zk.ErrNoNode {
+                return
+            }
+            if err == nil {
+                select {
+                case out <- &KVPair{Key: key, Value: decodeValue(raw), LastIndex: int64(stat.Version)}:
+                case <-stopCh:
+                    return
+                }
+            }
+
+            if err == zk.ErrNoNode {
+                // GetData does not arm a watch when the node is missing, so
+                // watch for its creation instead.
+                if _, err := s.client.curator.CheckExists().UsingWatcher(watcher).ForPath(key); err != nil {
+                    return
+                }
+            }
+
+            select {
+            case <-changed:
+            case <-stopCh:
+                return
+            }
+        }
+    }()
+    return out, nil
+}
+
+func (s *zkStore) WatchTree(prefix string, stopCh <-chan struct{}) (<-chan []*KVPair, error) {
+    out := make(chan []*KVPair, 1)
+    go func() {
+        defer close(out)
+        for {
+            changed := make(chan zk.Event, 1)
+            watcher := curator.NewWatcher(func(ev *zk.Event) {
+                select {
+                case changed <- *ev:
+                default:
+                }
+            })
+
+            children, err := s.client.curator.GetChildren().UsingWatcher(watcher).ForPath(prefix)
+            if err == zk.ErrNoNode {
+                // GetChildren does not arm a watch when prefix is missing, so
+                // watch for its creation instead.
+                if _, err := s.client.curator.CheckExists().UsingWatcher(watcher).ForPath(prefix); err != nil {
+                    return
+                }
+                select {
+                case <-changed:
+                case <-stopCh:
+                    return
+                }
+                continue
+            }
+            if err != nil {
+                return
+            }
+
+            pairs := make([]*KVPair, 0, len(children))
+            for _, child := range children {
+                if kv, err := s.Get(curator.JoinPath(prefix, child)); err == nil {
+                    pairs = append(pairs, kv)
+                }
+            }
+
+            select {
+            case out <- pairs:
+            case <-stopCh:
+                return
+            }
+
+            select {
+            case <-changed:
+            case <-stopCh:
+                return
+            }
+        }
+    }()
+    return out, nil
+}
+
+// encodeValue prefixes value with encodedValuePrefix when its first byte
+// would otherwise be mistaken for a ZooKeeper directory marker (NUL or SOH).
+func encodeValue(value []byte) []byte {
+    if len(value) > 0 && (value[0] == 0x00 || value[0] == 0x01) {
+        encoded := make([]byte, 0, len(value)+1)
+        encoded = append(encoded, encodedValuePrefix)
+        encoded = append(encoded, value...)
+        return encoded
+    }
+    return value
+}
+
+// decodeValue strips the prefix added by encodeValue, if present.
+func decodeValue(raw []byte) []byte {
+    if len(raw) > 0 && raw[0] == encodedValuePrefix {
+        return raw[1:]
+    }
+    return raw
+}
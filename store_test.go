@@ -0,0 +1,89 @@
+package zookclient
+
+import (
+    "testing"
+)
+
+func TestStore_AtomicPutCreateThenCAS(t *testing.T) {
+    connStr := embeddedZK(t)
+    client, err := NewZooKeeperClient(connStr)
+    if err != nil {
+        t.Fatalf("NewZooKeeperClient: %v", err)
+    }
+    store := client.NewStore()
+    key := "/test/store/atomicput"
+
+    ok, kv, err := store.AtomicPut(key, []byte("v1"), nil, nil)
+    if err != nil {
+        t.Fatalf("AtomicPut (create): %v", err)
+    }
+    if !ok {
+        t.Fatal("AtomicPut (create) reported false")
+    }
+
+    if ok, _, err := store.AtomicPut(key, []byte("v2"), nil, nil); err != ErrKeyExists {
+        t.Fatalf("AtomicPut (create) against existing key: ok=%v err=%v, want ErrKeyExists", ok, err)
+    }
+
+    ok, kv2, err := store.AtomicPut(key, []byte("v2"), kv, nil)
+    if err != nil {
+        t.Fatalf("AtomicPut (CAS): %v", err)
+    }
+    if !ok {
+        t.Fatal("AtomicPut (CAS) reported false")
+    }
+
+    if ok, _, err := store.AtomicPut(key, []byte("v3"), kv, nil); err != ErrKeyModified {
+        t.Fatalf("AtomicPut (stale CAS) ok=%v err=%v, want ErrKeyModified", ok, err)
+    }
+
+    got, err := store.Get(key)
+    if err != nil {
+        t.Fatalf("Get: %v", err)
+    }
+    if string(got.Value) != "v2" {
+        t.Fatalf("Get returned %q, want %q", got.Value, "v2")
+    }
+    if got.LastIndex != kv2.LastIndex {
+        t.Fatalf("Get LastIndex = %d, want %d", got.LastIndex, kv2.LastIndex)
+    }
+}
+
+func TestStore_AtomicDelete(t *testing.T) {
+    connStr := embeddedZK(t)
+    client, err := NewZooKeeperClient(connStr)
+    if err != nil {
+        t.Fatalf("NewZooKeeperClient: %v", err)
+    }
+    store := client.NewStore()
+    key := "/test/store/atomicdelete"
+
+    if _, _, err := store.AtomicPut(key, []byte("v1"), nil, nil); err != nil {
+        t.Fatalf("AtomicPut (create): %v", err)
+    }
+    kv, err := store.Get(key)
+    if err != nil {
+        t.Fatalf("Get: %v", err)
+    }
+
+    if _, err := store.AtomicDelete(key, nil); err != ErrPreviousNotSpecified {
+        t.Fatalf("AtomicDelete(nil) err=%v, want ErrPreviousNotSpecified", err)
+    }
+
+    stale := &KVPair{Key: key, Value: kv.Value, LastIndex: kv.LastIndex + 1}
+    if ok, err := store.AtomicDelete(key, stale); err != ErrKeyModified || ok {
+        t.Fatalf("AtomicDelete (stale) ok=%v err=%v, want false/ErrKeyModified", ok, err)
+    }
+
+    ok, err := store.AtomicDelete(key, kv)
+    if err != nil {
+        t.Fatalf("AtomicDelete: %v", err)
+    }
+    if !ok {
+        t.Fatal("AtomicDelete reported false")
+    }
+
+    if exists, err := store.Exists(key); err != nil || exists {
+        t.Fatalf("Exists after delete = %v, %v, want false, nil", exists, err)
+    }
+}
@@ -0,0 +1,68 @@
+package zookclient
+
+import (
+    "fmt"
+    "math/rand"
+    "os"
+    "os/exec"
+    "path/filepath"
+    "testing"
+    "time"
+
+    "github.com/samuel/go-zookeeper/zk"
+)
+
+// embeddedZK starts a single-node ZooKeeper server for the duration of a
+// test, using the standalone zkServer.sh distributed with a real ZooKeeper
+// install. The binary is located via the ZOOKEEPER_BIN environment variable
+// (falling back to $PATH); when neither is available the test is skipped
+// rather than faked, since there is nothing real to exercise the recipes
+// against.
+func embeddedZK(t *testing.T) (connStr string) {
+    t.Helper()
+    if testing.Short() {
+        t.Skip("embedded ZooKeeper tests skipped in -short mode")
+    }
+
+    bin := os.Getenv("ZOOKEEPER_BIN")
+    if bin == "" {
+        var err error
+        bin, err = exec.LookPath("zkServer.sh")
+        if err != nil {
+            t.Skip("zkServer.sh not found; set ZOOKEEPER_BIN to run embedded ZooKeeper tests")
+        }
+    }
+
+    dir, err := os.MkdirTemp("", "zookclient-test")
+    if err != nil {
+        t.Fatalf("could not create temp dir: %v", err)
+    }
+    t.Cleanup(func() { os.RemoveAll(dir) })
+
+    port := 20000 + rand.Intn(10000)
+    cfgPath := filepath.Join(dir, "zoo.cfg")
+    cfg := fmt.Sprintf("tickTime=2000\ndataDir=%s\nclientPort=%d\n", filepath.Join(dir, "data"), port)
+    if err := os.WriteFile(cfgPath, []byte(cfg), 0o644); err != nil {
+        t.Fatalf("could not write zoo.cfg: %v", err)
+    }
+
+    cmd := exec.Command(bin, "start-foreground", cfgPath)
+    if err := cmd.Start(); err != nil {
+        t.Skipf("could not start zkServer.sh: %v", err)
+    }
+    t.Cleanup(func() {
+        _ = cmd.Process.Kill()
+        _ = cmd.Wait()
+    })
+
+    connStr = fmt.Sprintf("127.0.0.1:%d", port)
+    deadline := time.Now().Add(30 * time.Second)
+    for time.Now().Before(deadline) {
+        if oks := zk.FLWRuok([]string{connStr}, time.Second); len(oks) == 1 && oks[0] {
+            return connStr
+        }
+        time.Sleep(200 * time.Millisecond)
+    }
+    t.Fatal("timed out waiting for embedded ZooKeeper to start")
+    return ""
+}
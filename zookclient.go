@@ -6,6 +6,7 @@ import (
     "github.com/pkg/errors"
     "github.com/samuel/go-zookeeper/zk"
     "log"
+    "sync"
     "time"
 )
 
@@ -36,19 +37,90 @@ type NodeInfo struct {
 type ZooKeeperClient struct {
     curator     curator.CuratorFramework
     retryPolicy *curator.ExponentialBackoffRetry
+
+    stateLock                  sync.RWMutex
+    state                      curator.ConnectionState
+    sessionEstablishedCallback func()
+    connectionStateCallback    func(state curator.ConnectionState)
+}
+
+// Option configures a ZooKeeperClient at construction time.
+type Option func(*ZooKeeperClient)
+
+// WithSessionEstablishedCallback registers a callback that fires once the
+// initial connection reaches CONNECTED, and again after every RECONNECTED
+// that follows a LOST session (curator-go has no separate EXPIRED state;
+// LOST is how it reports a confirmed session expiry). Applications can use
+// this to re-register ephemeral nodes, re-acquire locks, or refresh watches.
+func WithSessionEstablishedCallback(callback func()) Option {
+    return func(z *ZooKeeperClient) {
+        z.sessionEstablishedCallback = callback
+    }
 }
 
-func NewZooKeeperClient(connStr string) (*ZooKeeperClient, error) {
+// WithConnectionStateCallback registers a callback invoked with every
+// connection state transition reported by the underlying curator client.
+func WithConnectionStateCallback(callback func(state curator.ConnectionState)) Option {
+    return func(z *ZooKeeperClient) {
+        z.connectionStateCallback = callback
+    }
+}
+
+func NewZooKeeperClient(connStr string, opts ...Option) (*ZooKeeperClient, error) {
     log.Printf("connecting to %s", connStr)
     rp := curator.NewExponentialBackoffRetry(time.Second, 512, 15*time.Second)
     c := curator.NewClient(connStr, rp)
+
+    z := &ZooKeeperClient{curator: c, retryPolicy: rp}
+    for _, opt := range opts {
+        opt(z)
+    }
+    c.ConnectionStateListenable().AddListener(curator.NewConnectionStateListener(z.handleConnectionState))
+
     if err := c.Start(); err != nil {
         return nil, errors.Wrap(err, "could not start zookeeper-client")
     }
     if err := createRoot(c); err != nil {
         return nil, errors.Wrap(err, "could not create root")
     }
-    return &ZooKeeperClient{curator: c, retryPolicy: rp}, nil
+    return z, nil
+}
+
+func (z *ZooKeeperClient) handleConnectionState(client curator.CuratorFramework, newState curator.ConnectionState) {
+    z.stateLock.Lock()
+    previous := z.state
+    z.state = newState
+    z.stateLock.Unlock()
+
+    if z.connectionStateCallback != nil {
+        z.connectionStateCallback(newState)
+    }
+
+    if z.sessionEstablishedCallback != nil {
+        if newState == curator.CONNECTED || (newState == curator.RECONNECTED && previous == curator.LOST) {
+            z.sessionEstablishedCallback()
+        }
+    }
+}
+
+// State returns the most recently observed connection state.
+func (z *ZooKeeperClient) State() curator.ConnectionState {
+    z.stateLock.RLock()
+    defer z.stateLock.RUnlock()
+    return z.state
+}
+
+// SessionID returns the current ZooKeeper session id.
+func (z *ZooKeeperClient) SessionID() (int64, error) {
+    conn, err := z.curator.ZookeeperClient().Conn()
+    if err != nil {
+        return 0, err
+    }
+    zkConn, ok := conn.(*zk.Conn)
+    if !ok {
+        return 0, errors.New("underlying connection does not expose a session id")
+    }
+    return zkConn.SessionID(), nil
 }
 
 func (z *ZooKeeperClient) GetChildren(path string) ([]string, error) {
@@ -123,10 +195,16 @@ func (z *ZooKeeperClient) SetByte(path string, b []byte) error {
 }
 
 func (z *ZooKeeperClient) SetData(path string, data []byte, mode curator.CreateMode) error {
+    return z.SetDataWithOptions(path, data, mode, nil)
+}
+
+// SetDataWithOptions is SetData with an explicit ACL; a nil opts falls back
+// to curator.OPEN_ACL_UNSAFE, preserving SetData's existing behaviour.
+func (z *ZooKeeperClient) SetDataWithOptions(path string, data []byte, mode curator.CreateMode, opts *Options) error {
     var err error
     is, err := exists(z.curator, path)
     if !is || err != nil {
-        if _, err = z.curator.Create().WithMode(mode).CreatingParentsIfNeeded().ForPathWithData(path, data); err != nil {
+        if _, err = z.curator.Create().WithMode(mode).WithACL(opts.acl()...).CreatingParentsIfNeeded().ForPathWithData(path, data); err != nil {
             return err
         }
     } else {
@@ -167,25 +245,40 @@ func (z *ZooKeeperClient) CreateNode(path string, data []byte) error {
     return z.SetData(path, data, curator.PERSISTENT)
 }
 
+// CreateNodeWithOptions is CreateNode with an explicit ACL.
+func (z *ZooKeeperClient) CreateNodeWithOptions(path string, data []byte, opts *Options) error {
+    return z.SetDataWithOptions(path, data, curator.PERSISTENT, opts)
+}
+
 func (z *ZooKeeperClient) CreateEphemeralNode(path string, data []byte) error {
+    return z.CreateEphemeralNodeWithOptions(path, data, nil)
+}
+
+// CreateEphemeralNodeWithOptions is CreateEphemeralNode with an explicit ACL.
+func (z *ZooKeeperClient) CreateEphemeralNodeWithOptions(path string, data []byte, opts *Options) error {
     if z.Exists(path) {
         if err := z.curator.Delete().ForPath(path); err != nil {
             return err
         }
     }
-    if err := z.SetData(path, data, curator.EPHEMERAL); err != nil {
+    if err := z.SetDataWithOptions(path, data, curator.EPHEMERAL, opts); err != nil {
         return err
     }
     return nil
 }
 
 func (z *ZooKeeperClient) CreatePersistentNode(path string, obj interface{}) (interface{}, error) {
+    return z.CreatePersistentNodeWithOptions(path, obj, nil)
+}
+
+// CreatePersistentNodeWithOptions is CreatePersistentNode with an explicit ACL.
+func (z *ZooKeeperClient) CreatePersistentNodeWithOptions(path string, obj interface{}, opts *Options) (interface{}, error) {
     b, err := json.Marshal(obj)
     if err != nil {
         return nil, err
     }
-    p, err := z.curator.Create().WithMode(curator.EPHEMERAL).CreatingParentsIfNeeded().ForPathWithData(path, b)
-    if err != err {
+    p, err := z.curator.Create().WithMode(curator.EPHEMERAL).WithACL(opts.acl()...).CreatingParentsIfNeeded().ForPathWithData(path, b)
+    if err != nil {
         return nil, err
     }
     myWatcher := curator.NewWatcher(func(ev *zk.Event) {